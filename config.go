@@ -0,0 +1,39 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Config is the user's optional ~/.config/bgb/config.toml. Right now it only
+// lets users map self-hosted hostnames to one of the built-in remote link
+// providers, e.g.:
+//
+//	[hosts]
+//	"gitlab.acme.corp" = "gitlab"
+type Config struct {
+	Hosts map[string]string `toml:"hosts"`
+}
+
+// LoadConfig reads ~/.config/bgb/config.toml if it exists, returning an
+// empty Config when it doesn't.
+func LoadConfig() (*Config, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(home, ".config", "bgb", "config.toml")
+	cfg := &Config{Hosts: map[string]string{}}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if _, err := toml.DecodeFile(path, cfg); err != nil {
+		return nil, err
+	}
+	if cfg.Hosts == nil {
+		cfg.Hosts = map[string]string{}
+	}
+	return cfg, nil
+}
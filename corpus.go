@@ -0,0 +1,353 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"container/list"
+	"context"
+	"encoding/hex"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// corpusLogFormat mirrors `git log --format=%H %P %at %an %ae %s`, except it
+// uses \x1f (unit separator) between fields instead of spaces so that
+// multi-word author names don't throw off the split.
+const corpusLogFormat = "%H\x1f%P\x1f%at\x1f%an\x1f%ae\x1f%s"
+
+const defaultBlameCacheSize = 64
+
+// CommitHash is a 20-byte binary commit id, avoiding the 40-byte hex string
+// allocation that GitCommandArgs-based code pays for every comparison.
+type CommitHash [20]byte
+
+func (h CommitHash) String() string {
+	return hex.EncodeToString(h[:])
+}
+
+func parseCommitHash(s string) (CommitHash, error) {
+	var h CommitHash
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return h, err
+	}
+	if len(b) != len(h) {
+		return h, fmt.Errorf("expected a 40-character commit id, got %#v", s)
+	}
+	copy(h[:], b)
+	return h, nil
+}
+
+// GitCommit is one node of the in-memory DAG built by BuildCorpus.
+type GitCommit struct {
+	Hash       CommitHash
+	Parents    []CommitHash
+	AuthorTime int64
+	AuthorName string
+	AuthorMail string
+	Summary    string
+	// Path is the repo-relative, slash-separated path the tracked file had
+	// as of this commit, which can differ from later commits' Path across a
+	// rename.
+	Path string
+}
+
+type blameCacheKey struct {
+	commitId string
+	path     string
+}
+
+// Corpus is the in-memory commit DAG and blame cache for the file bgb was
+// opened on, built once at startup so that stepping through history (h/l)
+// doesn't have to re-fork git blame every time.
+type Corpus struct {
+	commits map[CommitHash]*GitCommit
+	order   []CommitHash // walk order, most recent first
+	// repoPath is the absolute repository root, used to resolve each
+	// commit's repo-relative Path back into the absolute paths the rest of
+	// the app works with (see PrewarmBlame).
+	repoPath string
+
+	cacheMu  sync.Mutex
+	cacheLL  *list.List
+	cacheMap map[blameCacheKey]*list.Element
+	cacheCap int
+
+	hits   int64
+	misses int64
+}
+
+type blameCacheEntry struct {
+	key   blameCacheKey
+	blame *Blame
+}
+
+// BuildCorpus walks the full history of path with `git log --follow
+// --name-status` and parses it into an in-memory DAG keyed by binary commit
+// hash. --name-status (scoped to path by the pathspec below) is what lets
+// each GitCommit record the file's own path as of that commit, which a bare
+// `git log --follow` doesn't expose.
+func BuildCorpus(gitArgs *GitCommandArgs, path string) (*Corpus, error) {
+	cmd := exec.CommandContext(
+		gitArgs.Context,
+		gitArgs.GitBinaryPath,
+		"-C",
+		gitArgs.RepoPath,
+		"log",
+		"--follow",
+		"--name-status",
+		"--format="+corpusLogFormat,
+		"--",
+		path,
+	)
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("error while executing git command: %s", strings.TrimSpace(stderr.String()))
+	}
+
+	corpus := &Corpus{
+		commits:  make(map[CommitHash]*GitCommit),
+		repoPath: gitArgs.RepoPath,
+		cacheLL:  list.New(),
+		cacheMap: make(map[blameCacheKey]*list.Element),
+		cacheCap: defaultBlameCacheSize,
+	}
+
+	scanner := bufio.NewScanner(&stdout)
+	var pending *GitCommit
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		if strings.Contains(line, "\x1f") {
+			fields := strings.SplitN(line, "\x1f", 6)
+			if len(fields) != 6 {
+				return nil, fmt.Errorf("unexpected format of line %#v in git log output.", line)
+			}
+			hash, err := parseCommitHash(fields[0])
+			if err != nil {
+				return nil, err
+			}
+			var parents []CommitHash
+			if fields[1] != "" {
+				for _, p := range strings.Fields(fields[1]) {
+					parentHash, err := parseCommitHash(p)
+					if err != nil {
+						return nil, err
+					}
+					parents = append(parents, parentHash)
+				}
+			}
+			authorTime, err := strconv.ParseInt(fields[2], 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := corpus.commits[hash]; ok {
+				pending = nil
+				continue
+			}
+			pending = &GitCommit{
+				Hash:       hash,
+				Parents:    parents,
+				AuthorTime: authorTime,
+				AuthorName: fields[3],
+				AuthorMail: fields[4],
+				Summary:    fields[5],
+			}
+			corpus.commits[hash] = pending
+			corpus.order = append(corpus.order, hash)
+			continue
+		}
+		// A --name-status line for the commit currently being parsed, e.g.
+		// "M\tfoo.txt" or "R100\tfoo.txt\tbar.txt" (old path, then new). The
+		// path as of this commit is always the last field.
+		if pending == nil {
+			continue
+		}
+		statusFields := strings.Split(line, "\t")
+		pending.Path = statusFields[len(statusFields)-1]
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return corpus, nil
+}
+
+// Get looks up a commit by its hex commit id.
+func (c *Corpus) Get(commitId string) (*GitCommit, bool) {
+	hash, err := parseCommitHash(commitId)
+	if err != nil {
+		return nil, false
+	}
+	commit, ok := c.commits[hash]
+	return commit, ok
+}
+
+// Entries returns every commit in the corpus, most recent first.
+func (c *Corpus) Entries() []*GitCommit {
+	entries := make([]*GitCommit, 0, len(c.order))
+	for _, hash := range c.order {
+		entries = append(entries, c.commits[hash])
+	}
+	return entries
+}
+
+// Len reports how many commits the corpus holds.
+func (c *Corpus) Len() int {
+	return len(c.order)
+}
+
+// Stats summarizes the blame cache's effectiveness for the :stats command.
+func (c *Corpus) Stats() (commits int, hits int64, misses int64) {
+	return c.Len(), atomic.LoadInt64(&c.hits), atomic.LoadInt64(&c.misses)
+}
+
+func (c *Corpus) cacheGet(key blameCacheKey) (*Blame, bool) {
+	c.cacheMu.Lock()
+	defer c.cacheMu.Unlock()
+	el, ok := c.cacheMap[key]
+	if !ok {
+		return nil, false
+	}
+	c.cacheLL.MoveToFront(el)
+	return el.Value.(*blameCacheEntry).blame, true
+}
+
+func (c *Corpus) cachePut(key blameCacheKey, blame *Blame) {
+	c.cacheMu.Lock()
+	defer c.cacheMu.Unlock()
+	if el, ok := c.cacheMap[key]; ok {
+		c.cacheLL.MoveToFront(el)
+		el.Value.(*blameCacheEntry).blame = blame
+		return
+	}
+	el := c.cacheLL.PushFront(&blameCacheEntry{key: key, blame: blame})
+	c.cacheMap[key] = el
+	if c.cacheLL.Len() > c.cacheCap {
+		oldest := c.cacheLL.Back()
+		if oldest != nil {
+			c.cacheLL.Remove(oldest)
+			delete(c.cacheMap, oldest.Value.(*blameCacheEntry).key)
+		}
+	}
+}
+
+// Blame is a thin lookup-then-populate layer over repo.Blame: it serves
+// (commitId, path) pairs out of the LRU cache when possible, and only falls
+// through to the Repository when the pair isn't cached (never blamed, or
+// evicted once more than cacheCap pairs have been blamed since). The working
+// tree (commitId == "") is never cached since it can change between calls.
+func (c *Corpus) Blame(ctx context.Context, repo Repository, commitId, path string) (*Blame, error) {
+	if commitId == "" {
+		atomic.AddInt64(&c.misses, 1)
+		return repo.Blame(ctx, commitId, path)
+	}
+
+	key := blameCacheKey{commitId: commitId, path: path}
+	if blame, ok := c.cacheGet(key); ok {
+		atomic.AddInt64(&c.hits, 1)
+		return blame, nil
+	}
+
+	atomic.AddInt64(&c.misses, 1)
+	blame, err := repo.Blame(ctx, commitId, path)
+	if err != nil {
+		return nil, err
+	}
+	c.cachePut(key, blame)
+	return blame, nil
+}
+
+// BlameStream is the incremental counterpart to Blame: a cache hit replays
+// its lines through onLine immediately, and a miss forwards onLine calls
+// live as repo.BlameStream parses them, caching the assembled result once
+// it finishes (working tree blames are never cached).
+func (c *Corpus) BlameStream(ctx context.Context, repo Repository, commitId, path string, onLine BlameLineHandler) error {
+	if commitId != "" {
+		if blame, ok := c.cacheGet(blameCacheKey{commitId: commitId, path: path}); ok {
+			atomic.AddInt64(&c.hits, 1)
+			for i, line := range blame.Lines {
+				onLine(i, blame.LineToChunkMap[i], line)
+			}
+			return nil
+		}
+	}
+
+	atomic.AddInt64(&c.misses, 1)
+	lineToChunkMap := make(map[int]*BlameChunk)
+	var lines []string
+	err := repo.BlameStream(ctx, commitId, path, func(lineNo int, chunk *BlameChunk, text string) {
+		for len(lines) <= lineNo {
+			lines = append(lines, "")
+		}
+		lines[lineNo] = text
+		lineToChunkMap[lineNo] = chunk
+		onLine(lineNo, chunk, text)
+	})
+	if err != nil {
+		return err
+	}
+	if commitId != "" {
+		c.cachePut(blameCacheKey{commitId: commitId, path: path}, &Blame{Lines: lines, LineToChunkMap: lineToChunkMap})
+	}
+	return nil
+}
+
+// PrewarmBlame eagerly blames the n most recent commits in the corpus in
+// the background, using each commit's own recorded Path so a prewarm call
+// past a rename boundary still blames the file under the name it actually
+// had. Only those exact commits are cached: picking one in the file log
+// panel is served from the cache, but h blames a line's parent commit,
+// which is a cache hit only when that parent is itself one of them.
+func (c *Corpus) PrewarmBlame(ctx context.Context, repo Repository, n int) {
+	if n > len(c.order) {
+		n = len(c.order)
+	}
+	for _, hash := range c.order[:n] {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+		path := filepath.Join(c.repoPath, filepath.FromSlash(c.commits[hash].Path))
+		_, _ = c.Blame(ctx, repo, hash.String(), path)
+	}
+}
+
+// RenamedPaths reports, for every repo-relative path this corpus has ever
+// known the tracked file by, whether blaming a commit under that path can
+// run into rename history further back in its ancestry. The oldest path the
+// corpus saw is never the product of a later rename within this history, so
+// it maps to false; every other path was necessarily renamed into at some
+// point, so it maps to true. GoGitRepository uses this to skip its own
+// per-call rename walk (see SetRenamedPaths).
+func (c *Corpus) RenamedPaths() map[string]bool {
+	result := make(map[string]bool)
+	if len(c.order) == 0 {
+		return result
+	}
+	var distinct []string
+	seen := make(map[string]bool)
+	for _, hash := range c.order {
+		p := c.commits[hash].Path
+		if !seen[p] {
+			seen[p] = true
+			distinct = append(distinct, p)
+		}
+	}
+	oldest := distinct[len(distinct)-1]
+	for _, p := range distinct {
+		result[p] = p != oldest
+	}
+	return result
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// ExecRepository implements Repository by forking the git binary on PATH
+// and parsing its porcelain output. It is the original backend bgb has
+// always used, and the only one that works when the repository can't be
+// opened directly (e.g. shallow clones or unsupported object formats).
+type ExecRepository struct {
+	GitBinaryPath string
+	RepoPath      string
+}
+
+func NewExecRepository(gitBinaryPath, repoPath string) *ExecRepository {
+	return &ExecRepository{GitBinaryPath: gitBinaryPath, RepoPath: repoPath}
+}
+
+// newCommand builds an exec.Cmd that runs in its own process group and, on
+// context cancellation, kills that whole group rather than just the git
+// process itself (git blame can still have helper processes in flight).
+func (r *ExecRepository) newCommand(ctx context.Context, args ...string) *exec.Cmd {
+	fullArgs := append([]string{"-C", r.RepoPath}, args...)
+	cmd := exec.CommandContext(ctx, r.GitBinaryPath, fullArgs...)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	cmd.Cancel = func() error {
+		return syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+	}
+	return cmd
+}
+
+func (r *ExecRepository) run(ctx context.Context, args ...string) (string, error) {
+	cmd := r.newCommand(ctx, args...)
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("error while executing git command: %s", strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+func (r *ExecRepository) ResolveTopLevel() (string, error) {
+	return r.run(context.Background(), "rev-parse", "--show-toplevel")
+}
+
+func (r *ExecRepository) RemoteURL() (string, error) {
+	return r.run(context.Background(), "ls-remote", "--get-url")
+}
+
+// Blame collects the full streamed result of BlameStream, for callers that
+// don't care about incremental progress.
+func (r *ExecRepository) Blame(ctx context.Context, commitId, filename string) (*Blame, error) {
+	lineToChunkMap := make(map[int]*BlameChunk)
+	var lines []string
+	err := r.BlameStream(ctx, commitId, filename, func(lineNo int, chunk *BlameChunk, text string) {
+		for len(lines) <= lineNo {
+			lines = append(lines, "")
+		}
+		lines[lineNo] = text
+		lineToChunkMap[lineNo] = chunk
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &Blame{Lines: lines, LineToChunkMap: lineToChunkMap}, nil
+}
+
+// BlameStream runs git blame --porcelain and invokes onLine as soon as each
+// line of the file has been fully parsed, instead of waiting for the whole
+// file. This keeps multi-thousand-line files from blocking the UI thread
+// for seconds, and lets the caller cancel ctx mid-stream to abort cleanly.
+func (r *ExecRepository) BlameStream(ctx context.Context, commitId, filename string, onLine BlameLineHandler) (err error) {
+	// git -C <repo> blame --porcelain <filename> [<commitId>]
+	argsCount := 3
+	if commitId != "" {
+		argsCount += 1
+	}
+	args := make([]string, 0, argsCount)
+	args = append(args, "blame")
+	args = append(args, "--porcelain")
+	args = append(args, filename)
+	if commitId != "" {
+		args = append(args, commitId)
+	}
+	cmd := r.newCommand(ctx, args...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	p, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	err = cmd.Start()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			return
+		}
+		err = cmd.Wait()
+		if err != nil {
+			err = fmt.Errorf("git blame command failed: %s", strings.TrimSpace(stderr.String()))
+		}
+	}()
+
+	scanner := bufio.NewScanner(p)
+	idToChunkMap := make(map[string]*BlameChunk)
+	linesInChunk := 0
+	lineNumber := 0
+	chunkPopulated := false
+	var chunk *BlameChunk
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if linesInChunk == 0 {
+			matches := BlameChunkHeader.FindStringSubmatch(line)
+			if matches == nil {
+				err = fmt.Errorf("unexpected format of line %#v in git blame output.", line)
+				return err
+			}
+			id := matches[1]
+			if idToChunkMap[id] != nil {
+				chunkPopulated = true
+				chunk = idToChunkMap[id]
+			} else {
+				chunkPopulated = false
+				chunk = &BlameChunk{}
+				chunk.CommitId = id
+				idToChunkMap[id] = chunk
+			}
+			lineNumber, err = strconv.Atoi(matches[3])
+			linesInChunk, err = strconv.Atoi(matches[4])
+			if err != nil {
+				return err
+			}
+			// convert to zero-indexed lines
+			lineNumber -= 1
+		} else if matches := LineInChunkHeader.FindStringSubmatch(line); matches != nil {
+			lineNumber, err = strconv.Atoi(matches[1])
+			if err != nil {
+				return err
+			}
+			// convert to zero-indexed lines
+			lineNumber -= 1
+		} else if strings.HasPrefix(line, "\t") {
+			linesInChunk -= 1
+			text := strings.Replace(line, "\t", "", 1)
+			onLine(lineNumber, chunk, text)
+		} else if !chunkPopulated {
+			if val, ok := FindInterestingValue(AuthorKey, line); ok {
+				chunk.Author = val
+			} else if val, ok := FindInterestingValue(AuthorMailKey, line); ok {
+				chunk.AuthorMail = val
+			} else if val, ok := FindInterestingValue(PreviousKey, line); ok {
+				chunk.PreviousCommitId = val[:40]
+				chunk.PreviousFilename = val[41:]
+			} else if val, ok := FindInterestingValue(SummaryKey, line); ok {
+				chunk.Summary = val
+			} else if val, ok := FindInterestingValue(FilenameKey, line); ok {
+				chunk.Filename = val
+			} else if val, ok := FindInterestingValue(AuthorTimeKey, line); ok {
+				timestamp, parseErr := strconv.ParseInt(val, 10, 64)
+				if parseErr != nil {
+					err = parseErr
+					return err
+				}
+				chunk.AuthorTime = timestamp
+			}
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if err = scanner.Err(); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package main
+
+import "path/filepath"
+
+// FileCommitLogEntry is the display-oriented view of a GitCommit used by
+// the file-scoped commit log panel.
+type FileCommitLogEntry struct {
+	CommitId string
+	Author   string
+	Date     int64
+	Subject  string
+	// Filename is the absolute path the tracked file had as of CommitId,
+	// which can differ from later entries' Filename across a rename.
+	Filename string
+}
+
+// FileCommitLogFromCorpus builds the file log panel's entries straight out
+// of the already-populated Corpus, most recent first, instead of forking a
+// second `git log` for the same information. repoPath resolves each
+// commit's repo-relative Path back into the absolute path the rest of the
+// app expects.
+func FileCommitLogFromCorpus(corpus *Corpus, repoPath string) []*FileCommitLogEntry {
+	commits := corpus.Entries()
+	entries := make([]*FileCommitLogEntry, len(commits))
+	for i, c := range commits {
+		entries[i] = &FileCommitLogEntry{
+			CommitId: c.Hash.String(),
+			Author:   c.AuthorName,
+			Date:     c.AuthorTime,
+			Subject:  c.Summary,
+			Filename: filepath.Join(repoPath, filepath.FromSlash(c.Path)),
+		}
+	}
+	return entries
+}
@@ -1,14 +1,9 @@
 package main
 
 import (
-	"bufio"
-	"bytes"
 	"context"
-	"fmt"
 	"net/url"
-	"os/exec"
 	"regexp"
-	"strconv"
 	"strings"
 )
 
@@ -26,6 +21,8 @@ const (
 
 const NotCommittedId = "0000000000000000000000000000000000000000"
 
+// GitCommandArgs bundles the bits a one-off git invocation needs that aren't
+// covered by the Repository interface.
 type GitCommandArgs struct {
 	Context       context.Context
 	GitBinaryPath string
@@ -53,160 +50,12 @@ type Blame struct {
 	LineToChunkMap map[int]*BlameChunk
 }
 
-func GitAttemptRepoLookup(gitArgs *GitCommandArgs) (string, error) {
-	cmd := exec.CommandContext(
-		gitArgs.Context,
-		gitArgs.GitBinaryPath,
-		"-C",
-		gitArgs.RepoPath,
-		"rev-parse",
-		"--show-toplevel",
-	)
-	var stdout bytes.Buffer
-	var stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-	if err := cmd.Run(); err != nil {
-		return "", fmt.Errorf("error while executing git command: %s", strings.TrimSpace(stderr.String()))
-	}
-	return strings.TrimSpace(stdout.String()), nil
-}
-
-func GitBlame(gitArgs *GitCommandArgs, commitId, filename string) (b *Blame, err error) {
-	// git -C <repo> blame --porcelain <filename> [<commitId>]
-	argsCount := 5
-	if commitId != "" {
-		argsCount += 1
-	}
-	args := make([]string, 0, argsCount)
-	args = append(args, "-C")
-	args = append(args, gitArgs.RepoPath)
-	args = append(args, "blame")
-	args = append(args, "--porcelain")
-	args = append(args, filename)
-	if commitId != "" {
-		args = append(args, commitId)
-	}
-	cmd := exec.CommandContext(gitArgs.Context, gitArgs.GitBinaryPath, args...)
-	var stderr bytes.Buffer
-	cmd.Stderr = &stderr
-	p, err := cmd.StdoutPipe()
-	if err != nil {
-		return nil, err
-	}
-	err = cmd.Start()
-	if err != nil {
-		return nil, err
-	}
-	defer func() {
-		if err != nil {
-			return
-		}
-		err = cmd.Wait()
-		if err != nil {
-			err = fmt.Errorf("git blame command failed: %s", strings.TrimSpace(stderr.String()))
-		}
-	}()
-
-	scanner := bufio.NewScanner(p)
-	lineToChunkMap := make(map[int]*BlameChunk)
-	idToChunkMap := make(map[string]*BlameChunk)
-	linesInChunk := 0
-	lineNumber := 0
-	chunkPopulated := false
-	var lines []string
-	var chunk *BlameChunk
-
-	for scanner.Scan() {
-		line := scanner.Text()
-		if linesInChunk == 0 {
-			matches := BlameChunkHeader.FindStringSubmatch(line)
-			if matches == nil {
-				err = fmt.Errorf("unexpected format of line %#v in git blame output.", line)
-				break
-			}
-			id := matches[1]
-			if idToChunkMap[id] != nil {
-				chunkPopulated = true
-				chunk = idToChunkMap[id]
-			} else {
-				chunkPopulated = false
-				chunk = &BlameChunk{}
-				chunk.CommitId = id
-				idToChunkMap[id] = chunk
-			}
-			lineNumber, err = strconv.Atoi(matches[3])
-			linesInChunk, err = strconv.Atoi(matches[4])
-			if err != nil {
-				return nil, err
-			}
-			// convert to zero-indexed lines
-			lineNumber -= 1
-		} else if matches := LineInChunkHeader.FindStringSubmatch(line); matches != nil {
-			lineNumber, err = strconv.Atoi(matches[1])
-			if err != nil {
-				return nil, err
-			}
-			// convert to zero-indexed lines
-			lineNumber -= 1
-		} else if strings.HasPrefix(line, "\t") {
-			linesInChunk -= 1
-			lineToChunkMap[lineNumber] = chunk
-			lines = append(lines, strings.Replace(line, "\t", "", 1))
-		} else if !chunkPopulated {
-			if val, ok := FindInterestingValue(AuthorKey, line); ok {
-				chunk.Author = val
-			} else if val, ok := FindInterestingValue(AuthorMailKey, line); ok {
-				chunk.AuthorMail = val
-			} else if val, ok := FindInterestingValue(PreviousKey, line); ok {
-				chunk.PreviousCommitId = val[:40]
-				chunk.PreviousFilename = val[41:]
-			} else if val, ok := FindInterestingValue(SummaryKey, line); ok {
-				chunk.Summary = val
-			} else if val, ok := FindInterestingValue(FilenameKey, line); ok {
-				chunk.Filename = val
-			} else if val, ok := FindInterestingValue(AuthorTimeKey, line); ok {
-				timestamp, err := strconv.ParseInt(val, 10, 64)
-				if err != nil {
-					return nil, err
-				}
-				chunk.AuthorTime = timestamp
-			}
-		}
-	}
-	if err != nil {
-		return nil, err
-	}
-	if err = scanner.Err(); err != nil {
-		return nil, err
-	}
-
-	b = &Blame{Lines: lines, LineToChunkMap: lineToChunkMap}
-	return b, nil
-}
-
-func GitFindRemoteInfo(gitArgs *GitCommandArgs) (*RemoteInfo, error) {
-	cmd := exec.CommandContext(
-		gitArgs.Context,
-		gitArgs.GitBinaryPath,
-		"-C",
-		gitArgs.RepoPath,
-		"ls-remote",
-		"--get-url",
-	)
-	var stdout bytes.Buffer
-	var stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-	if err := cmd.Run(); err != nil {
-		return nil, fmt.Errorf("error while executing git command: %s", strings.TrimSpace(stderr.String()))
-	}
-	raw := strings.TrimSpace(stdout.String())
-	ri, err := parseRemoteUrl(raw)
+func GitFindRemoteInfo(repo Repository) (*RemoteInfo, error) {
+	raw, err := repo.RemoteURL()
 	if err != nil {
 		return nil, err
 	}
-	return ri, nil
+	return parseRemoteUrl(raw)
 }
 
 func parseRemoteUrl(raw string) (*RemoteInfo, error) {
@@ -214,9 +63,10 @@ func parseRemoteUrl(raw string) (*RemoteInfo, error) {
 		raw = raw[:len(raw)-4]
 	}
 	var host, repo string
-	if strings.HasPrefix(raw, "git@") {
-		raw = strings.Replace(raw, "git@", "", 1)
-		hostAndRepoSlice := strings.SplitN(raw, ":", 2)
+	if !strings.Contains(raw, "://") && strings.Contains(raw, "@") && strings.Contains(raw, ":") {
+		// scp-like syntax, e.g. git@host:path or deploy@host:path
+		rest := raw[strings.Index(raw, "@")+1:]
+		hostAndRepoSlice := strings.SplitN(rest, ":", 2)
 		host = hostAndRepoSlice[0]
 		repo = hostAndRepoSlice[1]
 	} else {
@@ -224,7 +74,9 @@ func parseRemoteUrl(raw string) (*RemoteInfo, error) {
 		if err != nil {
 			return nil, err
 		}
-		host = u.Host
+		// Hostname() (unlike Host) strips the port, which ssh:// remotes
+		// can carry (e.g. ssh://git@host:2222/path).
+		host = u.Hostname()
 		repo = u.Path
 	}
 	return &RemoteInfo{Host: strings.Trim(host, "/"), Repo: strings.Trim(repo, "/")}, nil
@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	gogit "github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/utils/merkletrie"
+)
+
+// GoGitRepository implements Repository by reading objects directly out of
+// .git with go-git, without forking a git process. It's faster for repeated,
+// fine-grained actions (one blame per keypress) but falls back to
+// ExecRepository when it can't open the repository at all, or per-call when
+// go-git can't do the operation correctly (see hasRenameHistory).
+type GoGitRepository struct {
+	repo          *gogit.Repository
+	root          string
+	gitBinaryPath string
+	renamedPaths  map[string]bool
+}
+
+func NewGoGitRepository(gitBinaryPath, path string) (*GoGitRepository, error) {
+	repo, err := gogit.PlainOpenWithOptions(path, &gogit.PlainOpenOptions{DetectDotGit: true})
+	if err != nil {
+		return nil, err
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		return nil, err
+	}
+	return &GoGitRepository{repo: repo, root: wt.Filesystem.Root(), gitBinaryPath: gitBinaryPath}, nil
+}
+
+func (r *GoGitRepository) ResolveTopLevel() (string, error) {
+	return r.root, nil
+}
+
+// relPath converts an absolute filesystem path into the repo-root-relative,
+// slash-separated form that go-git's tree lookups expect. Every Repository
+// method on this type is called with the absolute paths bgb uses
+// everywhere else, so this is needed at every tree/commit lookup.
+func (r *GoGitRepository) relPath(path string) (string, error) {
+	rel, err := filepath.Rel(r.root, path)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// SetRenamedPaths tells the repository, for every repo-relative path the
+// Corpus has seen the tracked file under, whether that path has rename
+// history behind it (see Corpus.RenamedPaths). Blame consults this map
+// before falling back to its own hasRenameHistory walk, so once this is
+// set, blaming any of those paths is answered for free instead of walking
+// the commit graph. Paths it has no opinion on (including all of them, if
+// this is never called) still fall through to the walk.
+func (r *GoGitRepository) SetRenamedPaths(paths map[string]bool) {
+	r.renamedPaths = paths
+}
+
+func (r *GoGitRepository) RemoteURL() (string, error) {
+	remote, err := r.repo.Remote("origin")
+	if err != nil {
+		return "", err
+	}
+	urls := remote.Config().URLs
+	if len(urls) == 0 {
+		return "", fmt.Errorf("remote %#v has no URLs", "origin")
+	}
+	return urls[0], nil
+}
+
+func (r *GoGitRepository) Blame(ctx context.Context, commitId, path string) (*Blame, error) {
+	relPath, err := r.relPath(path)
+	if err != nil {
+		return nil, err
+	}
+	var commit *object.Commit
+	if commitId == "" {
+		var head *plumbing.Reference
+		head, err = r.repo.Head()
+		if err != nil {
+			return nil, err
+		}
+		commit, err = r.repo.CommitObject(head.Hash())
+	} else {
+		commit, err = r.repo.CommitObject(plumbing.NewHash(commitId))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// gogit.Blame doesn't do git's rename/copy attribution: it blames
+	// whatever is at relPath in each ancestor commit's tree, so a line
+	// written before a rename gets attributed to the rename commit instead
+	// of the commit that actually wrote it. ExecRepository gets this right
+	// (real git blame is rename-aware), so fall back to it whenever relPath
+	// has rename history anywhere in its ancestry.
+	renamed, ok := r.renamedPaths[relPath]
+	if !ok {
+		// SetRenamedPaths was never called for this path, so the corpus has
+		// nothing to say about it -- fall back to rediscovering it ourselves.
+		renamed, err = r.hasRenameHistory(ctx, commit, relPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if renamed {
+		return NewExecRepository(r.gitBinaryPath, r.root).Blame(ctx, commitId, path)
+	}
+
+	result, err := gogit.Blame(commit, relPath)
+	if err != nil {
+		return nil, err
+	}
+
+	lineToChunkMap := make(map[int]*BlameChunk)
+	idToChunkMap := make(map[string]*BlameChunk)
+	lines := make([]string, len(result.Lines))
+
+	for i, l := range result.Lines {
+		lines[i] = l.Text
+		id := l.Hash.String()
+		chunk, ok := idToChunkMap[id]
+		if !ok {
+			chunk = &BlameChunk{
+				CommitId:   id,
+				Author:     l.Author,
+				AuthorTime: l.Date.Unix(),
+				Filename:   relPath,
+			}
+			if lineCommit, err := r.repo.CommitObject(l.Hash); err == nil {
+				chunk.Summary = firstLine(lineCommit.Message)
+				if lineCommit.NumParents() > 0 {
+					chunk.PreviousCommitId = lineCommit.ParentHashes[0].String()
+					chunk.PreviousFilename = relPath
+				}
+			}
+			idToChunkMap[id] = chunk
+		}
+		lineToChunkMap[i] = chunk
+	}
+
+	return &Blame{Lines: lines, LineToChunkMap: lineToChunkMap}, nil
+}
+
+// BlameStream satisfies the Repository interface, but go-git's blame API
+// has no incremental form: it computes the whole result before this can
+// replay it through onLine.
+func (r *GoGitRepository) BlameStream(ctx context.Context, commitId, path string, onLine BlameLineHandler) error {
+	blame, err := r.Blame(ctx, commitId, path)
+	if err != nil {
+		return err
+	}
+	for i, line := range blame.Lines {
+		onLine(i, blame.LineToChunkMap[i], line)
+	}
+	return nil
+}
+
+// hasRenameHistory walks commit's first-parent chain looking for the point
+// where path entered the tree, and reports whether it got there by a rename
+// rather than being added fresh. Only the first-parent chain is walked, so a
+// rename that happened solely on a merged-in side branch won't be detected.
+//
+// This walk costs one tree lookup per intervening commit, so it's only used
+// as a fallback when SetRenamedPaths wasn't able to answer the question for
+// free; it checks ctx each iteration so a long walk over a long-lived file
+// can still be cancelled (e.g. by pressing Esc).
+func (r *GoGitRepository) hasRenameHistory(ctx context.Context, commit *object.Commit, path string) (bool, error) {
+	for commit.NumParents() > 0 {
+		if err := ctx.Err(); err != nil {
+			return false, err
+		}
+		parent, err := commit.Parent(0)
+		if err != nil {
+			return false, nil
+		}
+		if _, err := parent.File(path); err == nil {
+			commit = parent
+			continue
+		}
+		_, renamed := findRenameSource(commit, parent, path)
+		return renamed, nil
+	}
+	return false, nil
+}
+
+// findRenameSource looks for the entry in parent's tree that path was
+// renamed from by the time of commit. Tree.Diff already does its own rename
+// detection (a Modify change whose From/To names differ), so the common
+// case is just reading that back off the diff; the Delete case covers diff
+// options or go-git versions that don't detect the rename themselves, where
+// matching by exact blob content hash still finds a pure rename.
+func findRenameSource(commit, parent *object.Commit, path string) (string, bool) {
+	file, err := commit.File(path)
+	if err != nil {
+		return "", false
+	}
+	commitTree, err := commit.Tree()
+	if err != nil {
+		return "", false
+	}
+	parentTree, err := parent.Tree()
+	if err != nil {
+		return "", false
+	}
+	changes, err := parentTree.Diff(commitTree)
+	if err != nil {
+		return "", false
+	}
+	for _, c := range changes {
+		action, err := c.Action()
+		if err != nil {
+			continue
+		}
+		switch action {
+		case merkletrie.Modify:
+			if c.To.Name == path && c.From.Name != path {
+				return c.From.Name, true
+			}
+		case merkletrie.Delete:
+			if c.From.TreeEntry.Hash == file.Blob.Hash {
+				return c.From.Name, true
+			}
+		}
+	}
+	return "", false
+}
+
+func firstLine(s string) string {
+	for i, r := range s {
+		if r == '\n' {
+			return s[:i]
+		}
+	}
+	return s
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(),
+		"GIT_AUTHOR_NAME=test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=test", "GIT_COMMITTER_EMAIL=test@example.com",
+	)
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+// TestGoGitRepositoryBlameFollowsRenames builds a repo where a file is
+// renamed and then edited, and checks that Blame attributes the pre-rename
+// line to the commit that actually wrote it rather than the rename commit,
+// and reports repo-relative filenames, matching ExecRepository's behavior.
+// This guards against gogit.Blame's lack of rename/copy attribution, which
+// the go-git backend otherwise has no way to catch on its own.
+func TestGoGitRepositoryBlameFollowsRenames(t *testing.T) {
+	gitBin, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("git not found on PATH")
+	}
+
+	dir := t.TempDir()
+	runGit(t, dir, "init", "-q")
+
+	if err := os.WriteFile(filepath.Join(dir, "foo.txt"), []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, dir, "add", "foo.txt")
+	runGit(t, dir, "commit", "-q", "-m", "add foo")
+
+	runGit(t, dir, "mv", "foo.txt", "bar.txt")
+	runGit(t, dir, "commit", "-q", "-m", "rename foo to bar")
+
+	newPath := filepath.Join(dir, "bar.txt")
+	f, err := os.OpenFile(newPath, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString("world\n"); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	runGit(t, dir, "commit", "-q", "-am", "modify bar")
+
+	repo, err := NewGoGitRepository(gitBin, dir)
+	if err != nil {
+		t.Fatalf("NewGoGitRepository: %v", err)
+	}
+
+	blame, err := repo.Blame(context.Background(), "", newPath)
+	if err != nil {
+		t.Fatalf("Blame: %v", err)
+	}
+	if len(blame.Lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d: %v", len(blame.Lines), blame.Lines)
+	}
+
+	helloChunk := blame.LineToChunkMap[0]
+	if helloChunk.Summary != "add foo" {
+		t.Errorf("expected the first line to be attributed to %q, got %q", "add foo", helloChunk.Summary)
+	}
+	if helloChunk.Filename != "foo.txt" {
+		t.Errorf("expected the first line's filename to be %q, got %q", "foo.txt", helloChunk.Filename)
+	}
+
+	worldChunk := blame.LineToChunkMap[1]
+	if worldChunk.Summary != "modify bar" {
+		t.Errorf("expected the second line to be attributed to %q, got %q", "modify bar", worldChunk.Summary)
+	}
+	if worldChunk.Filename != "bar.txt" {
+		t.Errorf("expected the second line's filename to be %q, got %q", "bar.txt", worldChunk.Filename)
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// hunkContextLines controls how much surrounding, unchanged code GitShowHunk
+// includes around the hunk it returns, mirroring git show's own default -U3.
+const hunkContextLines = 3
+
+// HunkLine is one line of a diff hunk, with Kind set to the leading
+// unified-diff marker: ' ' for context, '+' for an addition, '-' for a
+// removal.
+type HunkLine struct {
+	Kind rune
+	Text string
+}
+
+// Hunk is the diff hunk that introduced a single blamed line, together with
+// the full commit message it came from, for the "why was this line
+// written" popup.
+type Hunk struct {
+	CommitId string
+	Subject  string
+	Body     string
+	Lines    []HunkLine
+}
+
+var hunkHeader = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+\d+(?:,\d+)? @@`)
+
+// GitShowHunk finds the diff hunk that introduced lineText and returns it
+// along with commitId's subject and body, so the blame view can show why a
+// line was written without leaving the TUI. It matches on the blamed line's
+// own text rather than its line number, since a line's position can drift
+// between the commit that introduced it and whichever commit the blame view
+// currently displays.
+func GitShowHunk(gitArgs *GitCommandArgs, commitId, filename, lineText string) (*Hunk, error) {
+	subject, body, err := gitCommitMessage(gitArgs, commitId)
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := runGitCommand(
+		gitArgs,
+		"show",
+		fmt.Sprintf("-U%d", hunkContextLines),
+		"--format=",
+		commitId,
+		"--",
+		filename,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := findHunk(out, lineText)
+	if lines == nil {
+		return nil, fmt.Errorf("no hunk adding %#v was found in %s's changes to %s", lineText, commitId, filename)
+	}
+
+	return &Hunk{CommitId: commitId, Subject: subject, Body: body, Lines: lines}, nil
+}
+
+func gitCommitMessage(gitArgs *GitCommandArgs, commitId string) (subject, body string, err error) {
+	out, err := runGitCommand(gitArgs, "show", "-s", "--format=%s\x1f%b", commitId)
+	if err != nil {
+		return "", "", err
+	}
+	fields := strings.SplitN(out, "\x1f", 2)
+	subject = fields[0]
+	if len(fields) > 1 {
+		body = strings.TrimSpace(fields[1])
+	}
+	return subject, body, nil
+}
+
+func runGitCommand(gitArgs *GitCommandArgs, args ...string) (string, error) {
+	fullArgs := append([]string{"-C", gitArgs.RepoPath}, args...)
+	cmd := exec.CommandContext(gitArgs.Context, gitArgs.GitBinaryPath, fullArgs...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("error while executing git command: %s", strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// findHunk walks the unified diff produced by `git show -U<n> --format=`
+// and returns the lines of the first hunk that adds a line matching
+// lineText, or nil if no hunk does. Blank or all-whitespace lineText is
+// ambiguous (most hunks touch one), so it isn't matched.
+func findHunk(diffOutput, lineText string) []HunkLine {
+	target := strings.TrimSpace(lineText)
+	var hunk []HunkLine
+	hasMatch := false
+	for _, raw := range strings.Split(diffOutput, "\n") {
+		if hunkHeader.MatchString(raw) {
+			if hasMatch {
+				return hunk
+			}
+			hunk = nil
+			continue
+		}
+		if raw == "" {
+			continue
+		}
+		switch raw[0] {
+		case '+', '-', ' ':
+			text := raw[1:]
+			hunk = append(hunk, HunkLine{Kind: rune(raw[0]), Text: text})
+			if target != "" && raw[0] == '+' && strings.TrimSpace(text) == target {
+				hasMatch = true
+			}
+		}
+	}
+	if hasMatch {
+		return hunk
+	}
+	return nil
+}
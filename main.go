@@ -7,6 +7,7 @@ import (
 	"os/exec"
 	"os/signal"
 	"path/filepath"
+	"runtime"
 	"strconv"
 	"strings"
 	"syscall"
@@ -26,9 +27,15 @@ const (
 	DisplayCommitIdLimit      = 7
 )
 
+// corpusPrewarmDepth is how many of the most recent corpus commits get
+// blamed in the background right after startup (see Corpus.PrewarmBlame).
+const corpusPrewarmDepth = 25
+
 const (
 	LineLinkCommand   = "ll"
 	CommitLinkCommand = "cl"
+	OpenLinkCommand   = "open"
+	StatsCommand      = "stats"
 )
 
 // this variable is set at compile time by the Makefile
@@ -38,22 +45,35 @@ type Application struct {
 	Context         context.Context
 	GitBin          string
 	RepoPath        string
+	Repo            Repository
 	CurrentCommitId string
+	CurrentFilename string
 	CursorPosition  int
 	CurrentBlame    *Blame
 	History         []*HistoryItem
 	SearchTerm      string
 	RemoteInfo      *RemoteInfo
+	Config          *Config
+	Corpus          *Corpus
+	FileLog         []*FileCommitLogEntry
 	TViewApp        *tview.Application
 	Ui              *AppUi
+	blameCancel     context.CancelFunc
+	loadGen         int
+	hunkCancel      context.CancelFunc
+	hunkGen         int
 }
 
 type AppUi struct {
-	Grid         *tview.Grid
-	Table        *tview.Table
-	BottomBar    *tview.TextView
-	InputBar     *tview.InputField
-	InputBarMode int
+	Grid           *tview.Grid
+	Table          *tview.Table
+	FileLogTable   *tview.Table
+	FileLogVisible bool
+	BottomBar      *tview.TextView
+	InputBar       *tview.InputField
+	InputBarMode   int
+	HunkView       *tview.TextView
+	Pages          *tview.Pages
 }
 
 type HistoryItem struct {
@@ -62,14 +82,6 @@ type HistoryItem struct {
 	Filename       string
 }
 
-func (a *Application) CreateGitArgs() *GitCommandArgs {
-	return &GitCommandArgs{
-		Context:       a.Context,
-		GitBinaryPath: a.GitBin,
-		RepoPath:      a.RepoPath,
-	}
-}
-
 func checkIfFile(path string) (bool, error) {
 	fi, err := os.Stat(path)
 	if err != nil {
@@ -116,56 +128,266 @@ func TimestampToRelative(timestamp int64) string {
 	}
 }
 
-func RenderBlameView(app *Application, blame *Blame) {
+func appendBlameRow(app *Application, i int, c *BlameChunk, line string) {
 	table := app.Ui.Table
-	table.Clear()
-	// app.CurrentBlame = blame
+	id := ""
+	age := ""
+	summary := "(not committed)"
+	if c.CommitId != NotCommittedId {
+		id = c.CommitId[:DisplayCommitIdLimit]
+		summary = firstN(c.Summary, DisplayMessageLengthLimit, true)
+		age = TimestampToRelative(c.AuthorTime)
+	}
+	var commitIdCell, summaryCell, ageCell, lineNoCell, lineCell *tview.TableCell
+	commitIdCell = tview.
+		NewTableCell(id).
+		SetTextColor(tcell.ColorYellow).
+		SetSelectable(false)
+
+	summaryCell = tview.
+		NewTableCell(tview.Escape(summary)).
+		SetSelectable(false)
+
+	ageCell = tview.
+		NewTableCell(age).
+		SetTextColor(tcell.ColorAqua).
+		SetSelectable(false)
+
+	lineNoCell = tview.
+		NewTableCell(strconv.Itoa(i + 1)).
+		SetAlign(tview.AlignRight).
+		SetSelectable(true)
+
+	lineCell = tview.
+		// tview has a bug where tabs in strings are completely stripped :(
+		NewTableCell(tview.Escape(strings.ReplaceAll(line, "\t", "    "))).
+		SetSelectable(true)
+
+	table.SetCell(i, 0, commitIdCell)
+	table.SetCell(i, 1, summaryCell)
+	table.SetCell(i, 2, ageCell)
+	table.SetCell(i, 3, lineNoCell)
+	table.SetCell(i, 4, lineCell)
+}
+
+func RenderBlameView(app *Application, blame *Blame) {
+	app.Ui.Table.Clear()
 	for i, line := range blame.Lines {
-		c := blame.LineToChunkMap[i]
-		id := ""
-		age := ""
-		summary := "(not committed)"
-		if c.CommitId != NotCommittedId {
-			id = c.CommitId[:DisplayCommitIdLimit]
-			summary = firstN(c.Summary, DisplayMessageLengthLimit, true)
-			age = TimestampToRelative(c.AuthorTime)
-		}
-		var commitIdCell, summaryCell, ageCell, lineNoCell, lineCell *tview.TableCell
-		commitIdCell = tview.
-			NewTableCell(id).
+		appendBlameRow(app, i, blame.LineToChunkMap[i], line)
+	}
+}
+
+// startBlameLoad cancels whatever blame is currently in flight and starts
+// streaming a new one in the background, appending rows to the table as
+// they arrive and showing a running line count in the BottomBar. onDone
+// runs on the UI goroutine once the whole file has been blamed
+// successfully; it's skipped if the load was cancelled or superseded by a
+// newer one.
+func startBlameLoad(app *Application, commitId, filename string, onDone func(*Blame)) {
+	if app.blameCancel != nil {
+		app.blameCancel()
+	}
+	ctx, cancel := context.WithCancel(app.Context)
+	app.blameCancel = cancel
+	app.loadGen++
+	gen := app.loadGen
+
+	app.Ui.Table.Clear()
+	setMessage(app, "Loading blame...")
+
+	go func() {
+		lineToChunkMap := make(map[int]*BlameChunk)
+		var lines []string
+		streamErr := app.Corpus.BlameStream(ctx, app.Repo, commitId, filename, func(lineNo int, chunk *BlameChunk, text string) {
+			for len(lines) <= lineNo {
+				lines = append(lines, "")
+			}
+			lines[lineNo] = text
+			lineToChunkMap[lineNo] = chunk
+			lineCount := len(lines)
+			app.TViewApp.QueueUpdateDraw(func() {
+				if gen != app.loadGen {
+					return
+				}
+				appendBlameRow(app, lineNo, chunk, text)
+				setMessage(app, fmt.Sprintf("Loading blame... (%d lines)", lineCount))
+			})
+		})
+		app.TViewApp.QueueUpdateDraw(func() {
+			if gen != app.loadGen {
+				return // a newer load has since started
+			}
+			app.blameCancel = nil
+			if streamErr != nil {
+				if ctx.Err() != nil {
+					setMessage(app, "Blame cancelled.")
+				} else {
+					setErrorMessage(app, fmt.Sprintf("%s", streamErr))
+				}
+				return
+			}
+			setMessage(app, "")
+			onDone(&Blame{Lines: lines, LineToChunkMap: lineToChunkMap})
+		})
+	}()
+}
+
+// startHunkLoad cancels whatever hunk lookup is currently in flight and
+// starts a new one in the background, mirroring startBlameLoad so the p key
+// doesn't block the UI thread on git show the way a synchronous call would.
+// onDone runs on the UI goroutine once the hunk is ready; it's skipped if
+// the load was cancelled or superseded by a newer one.
+func startHunkLoad(app *Application, commitId, filename, lineText string, onDone func(*Hunk)) {
+	if app.hunkCancel != nil {
+		app.hunkCancel()
+	}
+	ctx, cancel := context.WithCancel(app.Context)
+	app.hunkCancel = cancel
+	app.hunkGen++
+	gen := app.hunkGen
+
+	setMessage(app, "Loading hunk...")
+
+	go func() {
+		hunk, err := GitShowHunk(
+			&GitCommandArgs{Context: ctx, GitBinaryPath: app.GitBin, RepoPath: app.RepoPath},
+			commitId,
+			filename,
+			lineText,
+		)
+		app.TViewApp.QueueUpdateDraw(func() {
+			if gen != app.hunkGen {
+				return // a newer load has since started
+			}
+			app.hunkCancel = nil
+			if err != nil {
+				if ctx.Err() != nil {
+					setMessage(app, "Hunk lookup cancelled.")
+				} else {
+					setErrorMessage(app, fmt.Sprintf("%s", err))
+				}
+				return
+			}
+			setMessage(app, "")
+			onDone(hunk)
+		})
+	}()
+}
+
+func RenderFileLogView(app *Application) {
+	table := app.Ui.FileLogTable
+	table.Clear()
+	for i, e := range app.FileLog {
+		idCell := tview.
+			NewTableCell(e.CommitId[:DisplayCommitIdLimit]).
 			SetTextColor(tcell.ColorYellow).
 			SetSelectable(false)
 
-		summaryCell = tview.
-			NewTableCell(tview.Escape(summary)).
+		ageCell := tview.
+			NewTableCell(TimestampToRelative(e.Date)).
+			SetTextColor(tcell.ColorAqua).
 			SetSelectable(false)
 
-		ageCell = tview.
-			NewTableCell(age).
-			SetTextColor(tcell.ColorAqua).
+		authorCell := tview.
+			NewTableCell(tview.Escape(e.Author)).
 			SetSelectable(false)
 
-		lineNoCell = tview.
-			NewTableCell(strconv.Itoa(i + 1)).
-			SetAlign(tview.AlignRight).
+		subjectCell := tview.
+			NewTableCell(tview.Escape(firstN(e.Subject, DisplayMessageLengthLimit, true))).
 			SetSelectable(true)
 
-		lineCell = tview.
-			// tview has a bug where tabs in strings are completely stripped :(
-			NewTableCell(tview.Escape(strings.ReplaceAll(line, "\t", "    "))).
-			SetSelectable(true)
+		table.SetCell(i, 0, idCell)
+		table.SetCell(i, 1, ageCell)
+		table.SetCell(i, 2, authorCell)
+		table.SetCell(i, 3, subjectCell)
+	}
+}
+
+// toggleFileLogPanel shows or hides the file-scoped commit log panel,
+// rebuilding its entries from the corpus each time it's opened.
+func toggleFileLogPanel(app *Application) {
+	ui := app.Ui
+	if ui.FileLogVisible {
+		ui.Grid.RemoveItem(ui.FileLogTable)
+		ui.FileLogVisible = false
+		app.TViewApp.SetFocus(ui.Table)
+		return
+	}
+
+	app.FileLog = FileCommitLogFromCorpus(app.Corpus, app.RepoPath)
+	RenderFileLogView(app)
+	ui.FileLogTable.Select(0, 0)
+	ui.Grid.AddItem(ui.FileLogTable, 0, 1, 1, 1, 0, 0, false)
+	ui.FileLogVisible = true
+	app.TViewApp.SetFocus(ui.FileLogTable)
+}
+
+// centeredModal wraps p in a Grid so it renders as a fixed-size box
+// centered over whatever else is on the page, the standard tview pattern
+// for popups since tview has no built-in modal container for arbitrary
+// primitives.
+func centeredModal(p tview.Primitive, width, height int) tview.Primitive {
+	return tview.NewGrid().
+		SetColumns(0, width, 0).
+		SetRows(0, height, 0).
+		AddItem(p, 1, 1, 1, 1, 0, 0, true)
+}
 
-		table.SetCell(i, 0, commitIdCell)
-		table.SetCell(i, 1, summaryCell)
-		table.SetCell(i, 2, ageCell)
-		table.SetCell(i, 3, lineNoCell)
-		table.SetCell(i, 4, lineCell)
-	}
-	// newPos := app.CursorPosition
-	// if len(app.CurrentBlame.Lines) <= newPos {
-	// 	newPos = len(app.CurrentBlame.Lines) - 1
-	// }
-	// table.Select(newPos, 0)
+// showHunkPopup renders hunk into the hunk view and brings it to the front,
+// giving it focus so Esc/Enter can close it.
+func showHunkPopup(app *Application, hunk *Hunk) {
+	ui := app.Ui
+	var b strings.Builder
+	fmt.Fprintf(&b, "[yellow::b]%s[-:-:-]\n", tview.Escape(hunk.Subject))
+	if hunk.Body != "" {
+		fmt.Fprintf(&b, "\n%s\n", tview.Escape(hunk.Body))
+	}
+	b.WriteString("\n")
+	for _, line := range hunk.Lines {
+		text := tview.Escape(strings.ReplaceAll(line.Text, "\t", "    "))
+		switch line.Kind {
+		case '+':
+			fmt.Fprintf(&b, "[green]+%s[-]\n", text)
+		case '-':
+			fmt.Fprintf(&b, "[red]-%s[-]\n", text)
+		default:
+			fmt.Fprintf(&b, " %s\n", text)
+		}
+	}
+	ui.HunkView.SetText(b.String()).ScrollToBeginning()
+	ui.Pages.ShowPage("hunk")
+	app.TViewApp.SetFocus(ui.HunkView)
+}
+
+func closeHunkPopup(app *Application) {
+	ui := app.Ui
+	ui.Pages.HidePage("hunk")
+	app.TViewApp.SetFocus(ui.Table)
+}
+
+// selectFileLogEntry jumps the blame view to the commit at row in the file
+// log panel, preserving the ability to step back with the l key.
+func selectFileLogEntry(app *Application, row int) {
+	if row < 0 || row >= len(app.FileLog) {
+		return
+	}
+	entry := app.FileLog[row]
+	prevCommitId := app.CurrentCommitId
+	prevFilename := app.CurrentFilename
+	prevCursorPosition := app.CursorPosition
+	startBlameLoad(app, entry.CommitId, entry.Filename, func(blame *Blame) {
+		app.History = append(app.History, &HistoryItem{
+			CommitId:       prevCommitId,
+			CursorPosition: prevCursorPosition,
+			Filename:       prevFilename,
+		})
+		app.CurrentCommitId = entry.CommitId
+		app.CurrentFilename = entry.Filename
+		app.CurrentBlame = blame
+		app.CursorPosition = 0
+		app.Ui.Table.Select(0, 0)
+		toggleFileLogPanel(app)
+	})
 }
 
 func setErrorMessage(app *Application, message string) {
@@ -202,73 +424,90 @@ func performSearch(app *Application, reverse bool) bool {
 	return false
 }
 
-func buildLineLink(ri *RemoteInfo, id, path string, lineNumber int) (string, error) {
-	if ri.Host == "github.com" {
-		fullUrl := fmt.Sprintf(
-			"https://github.com/%s/blob/%s/%s#L%d",
-			ri.Repo,
-			id,
-			path,
-			lineNumber,
-		)
-		return fullUrl, nil
-	} else {
-		return "", fmt.Errorf("Cannot construct link for remote %s", ri.Host)
+func remoteInfoFor(app *Application) (*RemoteInfo, error) {
+	if app.RemoteInfo != nil {
+		return app.RemoteInfo, nil
+	}
+	ri, err := GitFindRemoteInfo(app.Repo)
+	if err != nil {
+		return nil, err
 	}
+	app.RemoteInfo = ri
+	return ri, nil
 }
 
-func buildCommitLink(ri *RemoteInfo, id string) (string, error) {
-	if ri.Host == "github.com" {
-		fullUrl := fmt.Sprintf(
-			"https://github.com/%s/commit/%s",
-			ri.Repo,
-			id,
-		)
-		return fullUrl, nil
-	} else {
-		return "", fmt.Errorf("Cannot construct link for remote %s", ri.Host)
+func currentLineLink(app *Application) (string, error) {
+	c := app.CurrentBlame.LineToChunkMap[app.CursorPosition]
+	if c.CommitId == NotCommittedId {
+		return "", fmt.Errorf("Cannot produce a remote link for the selected line because it's not committed")
+	}
+	ri, err := remoteInfoFor(app)
+	if err != nil {
+		return "", err
+	}
+	linker, err := ResolveRemoteLinker(ri.Host, app.Config)
+	if err != nil {
+		return "", err
 	}
+	return linker.LineLink(ri.Repo, c.CommitId, c.Filename, app.CursorPosition+1), nil
+}
+
+func currentCommitLink(app *Application) (string, error) {
+	id := app.CurrentBlame.LineToChunkMap[app.CursorPosition].CommitId
+	if id == NotCommittedId {
+		return "", fmt.Errorf("Cannot produce a remote link for the selected line because it's not committed")
+	}
+	ri, err := remoteInfoFor(app)
+	if err != nil {
+		return "", err
+	}
+	linker, err := ResolveRemoteLinker(ri.Host, app.Config)
+	if err != nil {
+		return "", err
+	}
+	return linker.CommitLink(ri.Repo, id), nil
+}
+
+func openInBrowser(ctx context.Context, url string) error {
+	opener := "xdg-open"
+	if runtime.GOOS == "darwin" {
+		opener = "open"
+	}
+	return exec.CommandContext(ctx, opener, url).Start()
+}
+
+func corpusStats(app *Application) (string, error) {
+	commits, hits, misses := app.Corpus.Stats()
+	total := hits + misses
+	hitRate := 0.0
+	if total > 0 {
+		hitRate = float64(hits) / float64(total) * 100
+	}
+	return fmt.Sprintf(
+		"corpus: %d commits, blame cache: %d hits / %d misses (%.0f%% hit rate)",
+		commits,
+		hits,
+		misses,
+		hitRate,
+	), nil
 }
 
 func handleCommand(app *Application, command string) (string, error) {
 	if command == LineLinkCommand {
-		c := app.CurrentBlame.LineToChunkMap[app.CursorPosition]
-		id := c.CommitId
-		if id == NotCommittedId {
-			return "", fmt.Errorf("Cannot produce a remote link for the selected line because it's not committed")
-		}
-		var ri *RemoteInfo
-		var err error
-		if app.RemoteInfo != nil {
-			ri = app.RemoteInfo
-		} else {
-			ri, err = GitFindRemoteInfo(app.CreateGitArgs())
-			if err != nil {
-				return "", err
-			}
-		}
-		return buildLineLink(
-			ri,
-			id,
-			c.Filename,
-			app.CursorPosition+1,
-		)
+		return currentLineLink(app)
 	} else if command == CommitLinkCommand {
-		id := app.CurrentBlame.LineToChunkMap[app.CursorPosition].CommitId
-		if id == NotCommittedId {
-			return "", fmt.Errorf("Cannot produce a remote link for the selected line because it's not committed")
+		return currentCommitLink(app)
+	} else if command == StatsCommand {
+		return corpusStats(app)
+	} else if command == OpenLinkCommand {
+		url, err := currentLineLink(app)
+		if err != nil {
+			return "", err
 		}
-		var ri *RemoteInfo
-		var err error
-		if app.RemoteInfo != nil {
-			ri = app.RemoteInfo
-		} else {
-			ri, err = GitFindRemoteInfo(app.CreateGitArgs())
-			if err != nil {
-				return "", err
-			}
+		if err := openInBrowser(app.Context, url); err != nil {
+			return "", fmt.Errorf("Failed to open %s: %s", url, err)
 		}
-		return buildCommitLink(ri, id)
+		return fmt.Sprintf("Opened %s", url), nil
 	} else {
 		return "", fmt.Errorf("Unknown command: %s", command)
 	}
@@ -284,20 +523,63 @@ func TViewInit(app *Application, filenameArg string) error {
 		table := tview.NewTable()
 		bottomBar := tview.NewTextView()
 		inputBar := tview.NewInputField()
+		hunkView := tview.NewTextView().
+			SetDynamicColors(true).
+			SetScrollable(true)
+		hunkView.
+			SetBorder(true).
+			SetTitle(" Hunk (Esc/Enter to close) ")
+		pages := tview.NewPages()
 		ui = &AppUi{
 			Grid:      grid,
 			Table:     table,
 			BottomBar: bottomBar,
 			InputBar:  inputBar,
+			HunkView:  hunkView,
+			Pages:     pages,
 		}
 	}
 	app.Ui = ui
 	tApp := app.TViewApp
 
+	fileLogTable := tview.NewTable()
+	ui.FileLogTable = fileLogTable
+
 	ui.Grid.
 		SetRows(0, 1).
+		SetColumns(0, 0).
 		AddItem(ui.Table, 0, 0, 1, 1, 0, 0, true).
-		AddItem(ui.BottomBar, 1, 0, 1, 1, 0, 0, false)
+		AddItem(ui.BottomBar, 1, 0, 1, 2, 0, 0, false)
+
+	ui.HunkView.
+		SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+			if event.Key() == tcell.KeyEscape || event.Key() == tcell.KeyEnter {
+				closeHunkPopup(app)
+				return nil
+			}
+			return event
+		})
+	ui.Pages.
+		AddPage("main", ui.Grid, true, true).
+		AddPage("hunk", centeredModal(ui.HunkView, 100, 30), true, false)
+
+	ui.FileLogTable.
+		SetSelectable(true, false).
+		SetEvaluateAllRows(true).
+		SetSelectedFunc(func(row, _ int) {
+			selectFileLogEntry(app, row)
+		}).
+		SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+			r := event.Rune()
+			if r == 99 || event.Key() == tcell.KeyEsc { // c key or Esc
+				toggleFileLogPanel(app)
+				return nil
+			} else if r == 113 { // q key
+				tApp.Stop()
+				return nil
+			}
+			return event
+		})
 
 	ui.Table.
 		SetSelectable(true, false).
@@ -307,6 +589,9 @@ func TViewInit(app *Application, filenameArg string) error {
 			UnhighlighCell(ui.Table.GetCell(app.CursorPosition, 4))
 			app.CursorPosition = row
 
+			if app.CurrentBlame == nil {
+				return
+			}
 			c := app.CurrentBlame.LineToChunkMap[row]
 			if c.CommitId != NotCommittedId {
 				details := fmt.Sprintf(
@@ -329,6 +614,16 @@ func TViewInit(app *Application, filenameArg string) error {
 			if r == 113 { // q key
 				tApp.Stop()
 				return nil
+			} else if event.Key() == tcell.KeyEscape {
+				if app.blameCancel != nil {
+					app.blameCancel()
+				}
+				if app.hunkCancel != nil {
+					app.hunkCancel()
+				}
+				return nil
+			} else if app.CurrentBlame == nil {
+				return nil // still loading the initial blame
 			} else if r == 104 { // h key
 				c := app.CurrentBlame.LineToChunkMap[app.CursorPosition]
 				if c.PreviousCommitId == "" {
@@ -341,29 +636,28 @@ func TViewInit(app *Application, filenameArg string) error {
 					)
 					return nil
 				}
-				blame, err := GitBlame(
-					app.CreateGitArgs(),
-					c.PreviousCommitId,
-					c.PreviousFilename,
-				)
-				if err != nil {
-					setErrorMessage(app, fmt.Sprintf("%s", err))
-					return nil
-				}
-				RenderBlameView(app, blame)
-				historyItem := &HistoryItem{
-					CommitId:       app.CurrentCommitId,
-					CursorPosition: app.CursorPosition,
-					Filename:       c.Filename,
-				}
-				app.History = append(app.History, historyItem)
-				app.CurrentCommitId = c.PreviousCommitId
-				app.CurrentBlame = blame
-				newPos := app.CursorPosition
-				if len(blame.Lines) <= newPos {
-					newPos = len(blame.Lines) - 1
-				}
-				ui.Table.Select(newPos, 0)
+				prevCommitId := app.CurrentCommitId
+				prevFilename := app.CurrentFilename
+				prevCursorPosition := app.CursorPosition
+				// PreviousFilename is repo-relative; Repository methods take
+				// absolute paths.
+				previousFilename := filepath.Join(app.RepoPath, filepath.FromSlash(c.PreviousFilename))
+				startBlameLoad(app, c.PreviousCommitId, previousFilename, func(blame *Blame) {
+					historyItem := &HistoryItem{
+						CommitId:       prevCommitId,
+						CursorPosition: prevCursorPosition,
+						Filename:       prevFilename,
+					}
+					app.History = append(app.History, historyItem)
+					app.CurrentCommitId = c.PreviousCommitId
+					app.CurrentFilename = previousFilename
+					app.CurrentBlame = blame
+					newPos := prevCursorPosition
+					if len(blame.Lines) <= newPos {
+						newPos = len(blame.Lines) - 1
+					}
+					ui.Table.Select(newPos, 0)
+				})
 				return nil
 			} else if r == 108 { // l key
 				historyLen := len(app.History)
@@ -377,24 +671,28 @@ func TViewInit(app *Application, filenameArg string) error {
 				filename := historyItem.Filename
 				newPos := historyItem.CursorPosition
 
-				blame, err := GitBlame(
-					app.CreateGitArgs(),
-					commitId,
-					filename,
-				)
-				if err != nil {
-					setErrorMessage(app, fmt.Sprintf("%s", err))
+				startBlameLoad(app, commitId, filename, func(blame *Blame) {
+					app.History[historyLen-1] = nil
+					app.History = app.History[:historyLen-1]
+
+					app.CurrentCommitId = commitId
+					app.CurrentFilename = filename
+					app.CurrentBlame = blame
+					ui.Table.Select(newPos, 0)
+				})
+				return nil
+			} else if r == 99 { // c key
+				toggleFileLogPanel(app)
+				return nil
+			} else if r == 112 { // p key
+				c := app.CurrentBlame.LineToChunkMap[app.CursorPosition]
+				if c.CommitId == NotCommittedId {
+					setErrorMessage(app, "(not committed)")
 					return nil
 				}
-
-				app.History[historyLen-1] = nil
-				app.History = app.History[:historyLen-1]
-				historyLen--
-
-				RenderBlameView(app, blame)
-				app.CurrentCommitId = commitId
-				app.CurrentBlame = blame
-				ui.Table.Select(newPos, 0)
+				startHunkLoad(app, c.CommitId, c.Filename, app.CurrentBlame.Lines[app.CursorPosition], func(hunk *Hunk) {
+					showHunkPopup(app, hunk)
+				})
 				return nil
 			} else if app.SearchTerm != "" && (r == 78 || r == 110) { // n or N (shift+n) key
 				reverse := r == 78
@@ -468,16 +766,14 @@ func TViewInit(app *Application, filenameArg string) error {
 	ui.BottomBar.
 		SetDynamicColors(true)
 
-	blame, err := GitBlame(app.CreateGitArgs(), "", filenameArg)
-	if err != nil {
-		return err
-	}
-	RenderBlameView(app, blame)
-	app.CurrentBlame = blame
-	ui.Table.Select(0, 0)
+	app.CurrentFilename = filenameArg
+	startBlameLoad(app, "", filenameArg, func(blame *Blame) {
+		app.CurrentBlame = blame
+		ui.Table.Select(0, 0)
+	})
 
-	tApp.SetRoot(ui.Grid, true)
-	err = tApp.Run()
+	tApp.SetRoot(ui.Pages, true)
+	err := tApp.Run()
 	return err
 }
 
@@ -507,16 +803,33 @@ func run() int {
 
 	ctx, cancel := context.WithCancel(context.Background())
 
-	gitArgs := &GitCommandArgs{
-		Context:       ctx,
-		GitBinaryPath: gitBin,
-		RepoPath:      filepath.Dir(fp),
+	fileDir := filepath.Dir(fp)
+	topLevelRepo := OpenRepository(gitBin, fileDir)
+	repoPath, err := topLevelRepo.ResolveTopLevel()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("bgb: %s", err))
+		return 1
+	}
+	repo := OpenRepository(gitBin, repoPath)
+
+	cfg, err := LoadConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, fmt.Errorf("bgb: %s", err))
+		return 1
 	}
-	repo, err := GitAttemptRepoLookup(gitArgs)
+
+	corpus, err := BuildCorpus(
+		&GitCommandArgs{Context: ctx, GitBinaryPath: gitBin, RepoPath: repoPath},
+		fp,
+	)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, fmt.Errorf("bgb: %s", err))
 		return 1
 	}
+	if gogitRepo, ok := repo.(*GoGitRepository); ok {
+		gogitRepo.SetRenamedPaths(corpus.RenamedPaths())
+	}
+	go corpus.PrewarmBlame(ctx, repo, corpusPrewarmDepth)
 
 	tApp := tview.NewApplication()
 	defer tApp.Stop()
@@ -530,7 +843,10 @@ func run() int {
 	app := Application{
 		GitBin:          gitBin,
 		Context:         ctx,
-		RepoPath:        repo,
+		RepoPath:        repoPath,
+		Repo:            repo,
+		Config:          cfg,
+		Corpus:          corpus,
 		CurrentCommitId: "",
 		CursorPosition:  0,
 		TViewApp:        tApp,
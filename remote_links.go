@@ -0,0 +1,97 @@
+package main
+
+import "fmt"
+
+// RemoteLinker knows how to build web URLs into a specific git hosting
+// provider's UI for a given repo/commit.
+type RemoteLinker interface {
+	LineLink(repo, commit, path string, line int) string
+	CommitLink(repo, commit string) string
+}
+
+type githubLinker struct{ host string }
+
+func (l githubLinker) LineLink(repo, commit, path string, line int) string {
+	return fmt.Sprintf("https://%s/%s/blob/%s/%s#L%d", l.host, repo, commit, path, line)
+}
+
+func (l githubLinker) CommitLink(repo, commit string) string {
+	return fmt.Sprintf("https://%s/%s/commit/%s", l.host, repo, commit)
+}
+
+type gitlabLinker struct{ host string }
+
+func (l gitlabLinker) LineLink(repo, commit, path string, line int) string {
+	return fmt.Sprintf("https://%s/%s/-/blob/%s/%s#L%d", l.host, repo, commit, path, line)
+}
+
+func (l gitlabLinker) CommitLink(repo, commit string) string {
+	return fmt.Sprintf("https://%s/%s/-/commit/%s", l.host, repo, commit)
+}
+
+type bitbucketLinker struct{ host string }
+
+func (l bitbucketLinker) LineLink(repo, commit, path string, line int) string {
+	return fmt.Sprintf("https://%s/%s/src/%s/%s#lines-%d", l.host, repo, commit, path, line)
+}
+
+func (l bitbucketLinker) CommitLink(repo, commit string) string {
+	return fmt.Sprintf("https://%s/%s/commits/%s", l.host, repo, commit)
+}
+
+type giteaLinker struct{ host string }
+
+func (l giteaLinker) LineLink(repo, commit, path string, line int) string {
+	return fmt.Sprintf("https://%s/%s/src/commit/%s/%s#L%d", l.host, repo, commit, path, line)
+}
+
+func (l giteaLinker) CommitLink(repo, commit string) string {
+	return fmt.Sprintf("https://%s/%s/commit/%s", l.host, repo, commit)
+}
+
+type sourcehutLinker struct{ host string }
+
+func (l sourcehutLinker) LineLink(repo, commit, path string, line int) string {
+	return fmt.Sprintf("https://%s/%s/tree/%s/item/%s#L%d", l.host, repo, commit, path, line)
+}
+
+func (l sourcehutLinker) CommitLink(repo, commit string) string {
+	return fmt.Sprintf("https://%s/%s/commit/%s", l.host, repo, commit)
+}
+
+// remoteLinkerFactories maps a provider name (as used in config.toml's
+// [hosts] table) to the constructor for it.
+var remoteLinkerFactories = map[string]func(host string) RemoteLinker{
+	"github":    func(host string) RemoteLinker { return githubLinker{host} },
+	"gitlab":    func(host string) RemoteLinker { return gitlabLinker{host} },
+	"bitbucket": func(host string) RemoteLinker { return bitbucketLinker{host} },
+	"gitea":     func(host string) RemoteLinker { return giteaLinker{host} },
+	"sourcehut": func(host string) RemoteLinker { return sourcehutLinker{host} },
+}
+
+// defaultHostProviders maps the well-known hostnames of each provider to its
+// name in remoteLinkerFactories, so most users never need a config file.
+var defaultHostProviders = map[string]string{
+	"github.com":    "github",
+	"gitlab.com":    "gitlab",
+	"bitbucket.org": "bitbucket",
+	"git.sr.ht":     "sourcehut",
+}
+
+// ResolveRemoteLinker picks a RemoteLinker for host, consulting cfg's
+// [hosts] table (e.g. for self-hosted GitLab/Gitea instances) when host
+// isn't one of the well-known providers.
+func ResolveRemoteLinker(host string, cfg *Config) (RemoteLinker, error) {
+	providerName, ok := defaultHostProviders[host]
+	if !ok && cfg != nil {
+		providerName, ok = cfg.Hosts[host]
+	}
+	if !ok {
+		return nil, fmt.Errorf("Cannot construct link for remote %s", host)
+	}
+	factory, ok := remoteLinkerFactories[providerName]
+	if !ok {
+		return nil, fmt.Errorf("Unknown remote link provider %#v for host %s", providerName, host)
+	}
+	return factory(host), nil
+}
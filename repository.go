@@ -0,0 +1,37 @@
+package main
+
+import "context"
+
+// BlameLineHandler is called once per line as BlameStream parses it, with
+// lineNo being the zero-indexed line number in the file.
+type BlameLineHandler func(lineNo int, chunk *BlameChunk, text string)
+
+// Repository abstracts the git operations bgb needs so that the TUI layer
+// does not have to care whether they are satisfied by shelling out to the
+// git binary or by reading objects directly out of .git. ExecRepository and
+// GoGitRepository are the two implementations shipped today.
+type Repository interface {
+	// ResolveTopLevel returns the absolute path to the root of the working
+	// tree that the repository was opened in.
+	ResolveTopLevel() (string, error)
+	// RemoteURL returns the raw fetch URL of the "origin" remote.
+	RemoteURL() (string, error)
+	// Blame annotates every line of path as of commitId ("" means the
+	// working tree / HEAD).
+	Blame(ctx context.Context, commitId, path string) (*Blame, error)
+	// BlameStream does the same work as Blame, but invokes onLine as soon
+	// as each line is parsed instead of waiting for the whole file, and
+	// can be aborted mid-stream by cancelling ctx.
+	BlameStream(ctx context.Context, commitId, path string, onLine BlameLineHandler) error
+}
+
+// OpenRepository tries to open repoPath with the faster go-git backend
+// first, since it doesn't need to fork a process for every action, and
+// falls back to shelling out to the git binary on PATH when that fails
+// (e.g. for repository features go-git doesn't support yet).
+func OpenRepository(gitBinaryPath, repoPath string) Repository {
+	if r, err := NewGoGitRepository(gitBinaryPath, repoPath); err == nil {
+		return r
+	}
+	return NewExecRepository(gitBinaryPath, repoPath)
+}